@@ -17,6 +17,10 @@
 #include "location.h"
 #include "buff_content.h"
 #include "datatype.h"
+#include "exec_env.h"
+#include "clock_sync.h"
+#include "mpit.h"
+#include "counts_checks.h"
 
 static SRCountNode_t *counts_head = NULL;
 static SRDisplNode_t *displs_head = NULL;
@@ -838,6 +842,16 @@ int _mpi_init(int *argc, char ***argv)
     logger = logger_init(jobid, world_rank, world_size, &allgatherv_logger_cfg);
     assert(logger);
 
+    // Save the tuning variables from the environment; they are assumed to be
+    // homogeneous across ranks so rank 0 saves them once for the entire job.
+    if (world_rank == 0)
+    {
+        save_exec_env(jobid, world_rank);
+    }
+
+    // Set up the MPI_T performance variable capture if the user requested it.
+    mpit_init(jobid, world_rank);
+
     // Allocate buffers reused between allgatherv calls
     // Note the buffer may be used on a communicator that is not comm_world
     // but in any case, it will be smaller or of the same size than comm_world.
@@ -881,6 +895,12 @@ int _mpi_init(int *argc, char ***argv)
     if (dump_call_data_envvar != NULL)
         dump_call_data = atoi(dump_call_data_envvar);
 
+#if ENABLE_EXEC_TIMING || ENABLE_LATE_ARRIVAL_TIMING
+    // Estimate the clock offset of every rank relative to rank 0 so the
+    // post-mortem analysis can correct cross-node timings for clock skew.
+    save_clock_offsets(jobid, world_rank, world_size);
+#endif // ENABLE_EXEC_TIMING || ENABLE_LATE_ARRIVAL_TIMING
+
     // Make sure we do not create an articial imbalance between ranks.
     PMPI_Barrier(MPI_COMM_WORLD);
 
@@ -917,6 +937,16 @@ int _mpi_init_thread(int *argc, char ***argv, int required, int *provided)
     logger = logger_init(jobid, world_rank, world_size, &allgatherv_logger_cfg);
     assert(logger);
 
+    // Save the tuning variables from the environment; they are assumed to be
+    // homogeneous across ranks so rank 0 saves them once for the entire job.
+    if (world_rank == 0)
+    {
+        save_exec_env(jobid, world_rank);
+    }
+
+    // Set up the MPI_T performance variable capture if the user requested it.
+    mpit_init(jobid, world_rank);
+
     // Allocate buffers reused between allgatherv calls
     // Note the buffer may be used on a communicator that is not comm_world
     // but in any case, it will be smaller or of the same size than comm_world.
@@ -960,6 +990,12 @@ int _mpi_init_thread(int *argc, char ***argv, int required, int *provided)
     if (dump_call_data_envvar != NULL)
         dump_call_data = atoi(dump_call_data_envvar);
 
+#if ENABLE_EXEC_TIMING || ENABLE_LATE_ARRIVAL_TIMING
+    // Estimate the clock offset of every rank relative to rank 0 so the
+    // post-mortem analysis can correct cross-node timings for clock skew.
+    save_clock_offsets(jobid, world_rank, world_size);
+#endif // ENABLE_EXEC_TIMING || ENABLE_LATE_ARRIVAL_TIMING
+
     // Make sure we do not create an articial imbalance between ranks.
     PMPI_Barrier(MPI_COMM_WORLD);
 
@@ -1145,6 +1181,8 @@ static int _release_profiling_resources()
 static int _finalize_profiling()
 {
     logger_fini(&logger);
+    mpit_fini();
+    counts_checks_fini();
     _release_profiling_resources();
     return 0;
 }
@@ -1385,6 +1423,10 @@ int _mpi_allgatherv(const void *sendbuf, const int sendcount, MPI_Datatype sendt
         double t_arrival = t_barrier_end - t_barrier_start;
 #endif // ENABLE_LATE_ARRIVAL_TIMING
 
+        // Read the tracked MPI_T performance variables right after the
+        // operation, while its effect on the transport is still visible.
+        mpit_record(collective_name, allgathervCalls);
+
 #if ENABLE_DISPLS
         // Gather receive displacements
         sbuf = NULL;
@@ -1508,6 +1550,15 @@ int _mpi_allgatherv(const void *sendbuf, const int sendcount, MPI_Datatype sendt
             }
 #endif // ENABLE_DISPLS
 
+#if ENABLE_COUNTS_CHECKS && !ENABLE_DISPLS
+            {
+                int _s_dt_size, _r_dt_size;
+                PMPI_Type_size(sendtype, &_s_dt_size);
+                PMPI_Type_size(recvtype, &_r_dt_size);
+                check_collective_counts(collective_name, allgathervCalls, comm_size, sbuf, 1, rbuf, comm_size, _s_dt_size, _r_dt_size, get_job_id(), world_rank);
+            }
+#endif // ENABLE_COUNTS_CHECKS && !ENABLE_DISPLS
+
 #if ((ENABLE_RAW_DATA || ENABLE_PER_RANK_STATS || ENABLE_VALIDATION) && ENABLE_COMPACT_FORMAT)
             DEBUG_ALLGATHERV_PROFILING("Saving data of call #%" PRIu64 ".\n", allgathervCalls);
             int s_dt_size, r_dt_size;
@@ -21,6 +21,10 @@
 #include "timings.h"
 #include "backtrace.h"
 #include "location.h"
+#include "exec_env.h"
+#include "clock_sync.h"
+#include "mpit.h"
+#include "counts_checks.h"
 
 static SRCountNode_t *counts_head = NULL;
 static SRDisplNode_t *displs_head = NULL;
@@ -798,6 +802,16 @@ int _mpi_init(int *argc, char ***argv)
 	logger = logger_init(jobid, world_rank, world_size, &alltoall_logger_cfg);
 	assert(logger);
 
+	// Save the tuning variables from the environment; they are assumed to be
+	// homogeneous across ranks so rank 0 saves them once for the entire job.
+	if (world_rank == 0)
+	{
+		save_exec_env(jobid, world_rank);
+	}
+
+	// Set up the MPI_T performance variable capture if the user requested it.
+	mpit_init(jobid, world_rank);
+
 	// Allocate buffers reused between alltoall calls
 	// Note the buffer may be used on a communicator that is not comm_world
 	// but in any case, it will be smaller or of the same size than comm_world.
@@ -821,6 +835,12 @@ int _mpi_init(int *argc, char ***argv)
 	srand((unsigned)getpid());
 #endif
 
+#if ENABLE_EXEC_TIMING || ENABLE_LATE_ARRIVAL_TIMING
+	// Estimate the clock offset of every rank relative to rank 0 so the
+	// post-mortem analysis can correct cross-node timings for clock skew.
+	save_clock_offsets(jobid, world_rank, world_size);
+#endif // ENABLE_EXEC_TIMING || ENABLE_LATE_ARRIVAL_TIMING
+
 	// Make sure we do not create an articial imbalance between ranks.
 	MPI_Barrier(MPI_COMM_WORLD);
 
@@ -963,6 +983,8 @@ static int _release_profiling_resources()
 static int _finalize_profiling()
 {
 	logger_fini(&logger);
+	mpit_fini();
+	counts_checks_fini();
 	_release_profiling_resources();
 }
 
@@ -1110,6 +1132,10 @@ int _mpi_alltoall(const void *sendbuf, const int sendcount, MPI_Datatype sendtyp
 		double t_arrival = t_barrier_end - t_barrier_start;
 #endif // ENABLE_LATE_ARRIVAL_TIMING
 
+		// Read the tracked MPI_T performance variables right after the
+		// operation, while its effect on the transport is still visible.
+		mpit_record(collective_name, avCalls);
+
 #if ASSUME_COUNTS_EQUAL_ALL_RANKS != 1
 		// Gather a bunch of counters
 		// TODO this gather is to rank 0, but which rank does the noting and reporting. 
@@ -1177,6 +1203,15 @@ int _mpi_alltoall(const void *sendbuf, const int sendcount, MPI_Datatype sendtyp
 			fprintf(logger->f, "Root: global %d - %d   local %d - %d\n", world_size, myrank, size, localrank);
 #endif
 
+#if ENABLE_COUNTS_CHECKS
+			{
+				int _s_dt_size, _r_dt_size;
+				PMPI_Type_size(sendtype, &_s_dt_size);
+				PMPI_Type_size(recvtype, &_r_dt_size);
+				check_collective_counts(collective_name, avCalls, comm_size, sbuf, 1, rbuf, 1, _s_dt_size, _r_dt_size, get_job_id(), world_rank);
+			}
+#endif // ENABLE_COUNTS_CHECKS
+
 #if ((ENABLE_RAW_DATA || ENABLE_PER_RANK_STATS || ENABLE_VALIDATION) && ENABLE_COMPACT_FORMAT)
 			int s_dt_size, r_dt_size;
 			MPI_Type_size(sendtype, &s_dt_size);
@@ -17,6 +17,10 @@
 #include "location.h"
 #include "buff_content.h"
 #include "datatype.h"
+#include "exec_env.h"
+#include "clock_sync.h"
+#include "mpit.h"
+#include "counts_checks.h"
 
 static SRCountNode_t *counts_head = NULL;
 static SRDisplNode_t *displs_head = NULL;
@@ -556,6 +560,334 @@ static int insert_sendrecv_count_data(int *sbuf, int *rbuf, int size, int sendty
 	return 0;
 }
 
+static displs_data_t *lookupDispls(int size, int num, displs_data_t **list, int *displs)
+{
+	int i, j;
+	for (i = 0; i < num; i++)
+	{
+		for (j = 0; j < size; j++)
+		{
+			if (displs[j] != list[i]->displs[j])
+			{
+				break;
+			}
+		}
+
+		if (j == size)
+		{
+			return list[i];
+		}
+	}
+
+	return NULL;
+}
+
+static int *lookupRankSendDispls(SRDisplNode_t *call_data, int rank)
+{
+	return lookup_rank_displs(call_data->send_data_size, call_data->send_data, rank);
+}
+
+static int *lookupRankRecvDispls(SRDisplNode_t *call_data, int rank)
+{
+	return lookup_rank_displs(call_data->recv_data_size, call_data->recv_data, rank);
+}
+
+// Compare if two arrays are identical.
+static bool same_call_displs(SRDisplNode_t *call_data, int *send_displs, int *recv_displs, int size)
+{
+	int num = 0;
+	int rank, displ_num;
+
+	DEBUG_ALLTOALLV_PROFILING("Comparing data with existing data...\n");
+	DEBUG_ALLTOALLV_PROFILING("-> Comparing send displacements...\n");
+	// First compare the send displacements
+	for (rank = 0; rank < size; rank++)
+	{
+		int *_displs = lookupRankSendDispls(call_data, rank);
+		assert(_displs);
+		for (displ_num = 0; displ_num < size; displ_num++)
+		{
+			if (_displs[displ_num] != send_displs[num])
+			{
+				DEBUG_ALLTOALLV_PROFILING("Data differs\n");
+				return false;
+			}
+			num++;
+		}
+	}
+	DEBUG_ALLTOALLV_PROFILING("-> Send displacements are the same\n");
+
+	// Then the receive displacements
+	DEBUG_ALLTOALLV_PROFILING("-> Comparing recv displacements...\n");
+	num = 0;
+	for (rank = 0; rank < size; rank++)
+	{
+		int *_displs = lookupRankRecvDispls(call_data, rank);
+		for (displ_num = 0; displ_num < size; displ_num++)
+		{
+			if (_displs[displ_num] != recv_displs[num])
+			{
+				DEBUG_ALLTOALLV_PROFILING("Data differs\n");
+				return false;
+			}
+			num++;
+		}
+	}
+
+	DEBUG_ALLTOALLV_PROFILING("Data is the same\n");
+	return true;
+}
+
+static displs_data_t *lookupSendDispls(int *displs, SRDisplNode_t *call_data)
+{
+	return lookupDispls(call_data->size, call_data->send_data_size, call_data->send_data, displs);
+}
+
+static displs_data_t *lookupRecvDispls(int *displs, SRDisplNode_t *call_data)
+{
+	return lookupDispls(call_data->size, call_data->recv_data_size, call_data->recv_data, displs);
+}
+
+static int add_rank_to_displs_data(int rank, displs_data_t *displs_data)
+{
+	if (displs_data->num_ranks >= displs_data->max_ranks)
+	{
+		displs_data->max_ranks = displs_data->num_ranks + MAX_TRACKED_RANKS;
+		displs_data->ranks = (int *)realloc(displs_data->ranks, displs_data->max_ranks * sizeof(int));
+		assert(displs_data->ranks);
+	}
+
+	displs_data->ranks[displs_data->num_ranks] = rank;
+	displs_data->num_ranks++;
+	return 0;
+}
+
+static void delete_displs_data(displs_data_t **data)
+{
+	if (*data)
+	{
+		if ((*data)->ranks)
+		{
+			free((*data)->ranks);
+		}
+		if ((*data)->displs)
+		{
+			free((*data)->displs);
+		}
+		free(*data);
+		*data = NULL;
+	}
+}
+
+static displs_data_t *new_displ_data(int size, int rank, int *displs)
+{
+	int i;
+	displs_data_t *new_data = (displs_data_t *)malloc(sizeof(displs_data_t));
+	assert(new_data);
+	new_data->displs = (int *)malloc(size * sizeof(int));
+	assert(new_data->displs);
+	new_data->num_ranks = 0;
+	new_data->max_ranks = MAX_TRACKED_RANKS;
+	new_data->ranks = (int *)malloc(new_data->max_ranks * sizeof(int));
+	assert(new_data->ranks);
+
+	for (i = 0; i < size; i++)
+	{
+		new_data->displs[i] = displs[i];
+	}
+	new_data->ranks[new_data->num_ranks] = rank;
+	new_data->num_ranks++;
+
+	return new_data;
+}
+
+static int add_new_send_displs_to_displs_data(SRDisplNode_t *call_data, int rank, int *displs)
+{
+	displs_data_t *new_data = new_displ_data(call_data->size, rank, displs);
+	call_data->send_data[call_data->send_data_size] = new_data;
+	call_data->send_data_size++;
+
+	return 0;
+}
+
+static int add_new_recv_displs_to_displs_data(SRDisplNode_t *call_data, int rank, int *displs)
+{
+	displs_data_t *new_data = new_displ_data(call_data->size, rank, displs);
+	call_data->recv_data[call_data->recv_data_size] = new_data;
+	call_data->recv_data_size++;
+
+	return 0;
+}
+
+static int compareAndSaveSendDispls(int rank, int *displs, SRDisplNode_t *call_data)
+{
+	displs_data_t *ptr = lookupSendDispls(displs, call_data);
+	if (ptr)
+	{
+		DEBUG_ALLTOALLV_PROFILING("Add send rank %d to existing displacements data\n", rank);
+		if (add_rank_to_displs_data(rank, ptr))
+		{
+			fprintf(stderr, "[%s:%d][ERROR] unable to add rank displacements (rank: %d)\n", __FILE__, __LINE__, rank);
+			return -1;
+		}
+	}
+	else
+	{
+		DEBUG_ALLTOALLV_PROFILING("Add send new displacement data for rank %d\n", rank);
+		if (add_new_send_displs_to_displs_data(call_data, rank, displs))
+		{
+			fprintf(stderr, "[%s:%d][ERROR] unable to add new send displacements\n", __FILE__, __LINE__);
+			return -1;
+		}
+	}
+
+	return 0;
+}
+
+static int compareAndSaveRecvDispls(int rank, int *displs, SRDisplNode_t *call_data)
+{
+	displs_data_t *ptr = lookupRecvDispls(displs, call_data);
+	if (ptr)
+	{
+		DEBUG_ALLTOALLV_PROFILING("Add recv rank %d to existing displacements data\n", rank);
+		if (add_rank_to_displs_data(rank, ptr))
+		{
+			fprintf(stderr, "[ERROR] unable to add rank displacements\n");
+			return -1;
+		}
+	}
+	else
+	{
+		DEBUG_ALLTOALLV_PROFILING("Add recv new displacement data for rank %d\n", rank);
+		if (add_new_recv_displs_to_displs_data(call_data, rank, displs))
+		{
+			fprintf(stderr, "[ERROR] unable to add new recv displacements\n");
+			return -1;
+		}
+	}
+
+	return 0;
+}
+
+// Compare new send/recv displacement data with existing data.
+// If there is a match, increase the counter. Add new data, otherwise.
+static int insert_sendrecv_displ_data(int *sbuf, int *rbuf, int size, int sendtype_size, int recvtype_size)
+{
+	int num = 0;
+	struct SRDisplNode *newNode = NULL;
+	struct SRDisplNode *temp;
+
+	DEBUG_ALLTOALLV_PROFILING("Insert displacement data for a new alltoallv call...\n");
+
+	assert(sbuf);
+	assert(rbuf);
+	assert(logger);
+
+	temp = displs_head;
+	while (temp != NULL)
+	{
+		if (temp->size != size || temp->recvtype_size != recvtype_size || temp->sendtype_size != sendtype_size || !same_call_displs(temp, sbuf, rbuf, size))
+		{
+			// New data
+#if DEBUG
+			fprintf(logger->f, "new data: %d\n", size);
+#endif
+			if (temp->next != NULL)
+				temp = temp->next;
+			else
+				break;
+		}
+		else
+		{
+			// Data exist, adding call info to it
+			DEBUG_ALLTOALLV_PROFILING("Displacement data already exists, updating metadata...\n");
+			assert(temp->list_calls);
+			if (temp->count >= temp->max_calls)
+			{
+				temp->max_calls = temp->max_calls * 2;
+				temp->list_calls = (uint64_t *)realloc(temp->list_calls, temp->max_calls * sizeof(uint64_t));
+				assert(temp->list_calls);
+			}
+			temp->list_calls[temp->count] = avCalls; // Note: count starts at 1, not 0
+			temp->count++;
+#if DEBUG
+			fprintf(logger->f, "old data: %d --> %d --- %d\n", size, temp->size, temp->count);
+#endif
+			DEBUG_ALLTOALLV_PROFILING("Metadata successfully updated\n");
+			return 0;
+		}
+	}
+
+#if DEBUG
+	fprintf(logger->f, "no data: %d \n", size);
+#endif
+	newNode = (struct SRDisplNode *)malloc(sizeof(SRDisplNode_t));
+	assert(newNode);
+
+	newNode->size = size;
+	newNode->rank_send_vec_len = size;
+	newNode->rank_recv_vec_len = size;
+	newNode->count = 1;
+	newNode->list_calls = (uint64_t *)malloc(DEFAULT_TRACKED_CALLS * sizeof(uint64_t));
+	assert(newNode->list_calls);
+	newNode->max_calls = DEFAULT_TRACKED_CALLS;
+	// We have at most <size> different displacement series (one per rank) and we just allocate pointers of pointers here, not much space used
+	newNode->send_data = (displs_data_t **)malloc(size * sizeof(displs_data_t));
+	assert(newNode->send_data);
+	newNode->send_data_size = 0;
+	newNode->recv_data = (displs_data_t **)malloc(size * sizeof(displs_data_t));
+	assert(newNode->recv_data);
+	newNode->recv_data_size = 0;
+
+	// We add rank's data one by one so we can compress the data when possible
+	num = 0;
+	int _rank;
+
+	DEBUG_ALLTOALLV_PROFILING("handling send displacements...\n");
+	for (_rank = 0; _rank < size; _rank++)
+	{
+		if (compareAndSaveSendDispls(_rank, &(sbuf[num * size]), newNode))
+		{
+			fprintf(stderr, "[%s:%d][ERROR] unable to add send displacements\n", __FILE__, __LINE__);
+			return -1;
+		}
+		num++;
+	}
+
+	DEBUG_ALLTOALLV_PROFILING("handling recv displacements...\n");
+	num = 0;
+	for (_rank = 0; _rank < size; _rank++)
+	{
+		if (compareAndSaveRecvDispls(_rank, &(rbuf[num * size]), newNode))
+		{
+			fprintf(stderr, "[%s:%d][ERROR] unable to add recv displacements\n", __FILE__, __LINE__);
+			return -1;
+		}
+		num++;
+	}
+
+	newNode->sendtype_size = sendtype_size;
+	newNode->recvtype_size = recvtype_size;
+	newNode->list_calls[0] = avCalls;
+	newNode->next = NULL;
+#if DEBUG
+	fprintf(logger->f, "new entry: %d --> %d --- %d\n", size, newNode->size, newNode->count);
+#endif
+
+	DEBUG_ALLTOALLV_PROFILING("Data for the new alltoallv call has %d unique series for send displs and %d for recv displs\n", newNode->send_data_size, newNode->recv_data_size);
+
+	if (displs_head == NULL)
+	{
+		displs_head = newNode;
+	}
+	else
+	{
+		temp->next = newNode;
+	}
+
+	return 0;
+}
+
 static void display_per_host_data(int size)
 {
 	int i;
@@ -754,6 +1086,16 @@ int _mpi_init(int *argc, char ***argv)
 	logger = logger_init(jobid, world_rank, world_size, &alltoallv_logger_cfg);
 	assert(logger);
 
+	// Save the tuning variables from the environment; they are assumed to be
+	// homogeneous across ranks so rank 0 saves them once for the entire job.
+	if (world_rank == 0)
+	{
+		save_exec_env(jobid, world_rank);
+	}
+
+	// Set up the MPI_T performance variable capture if the user requested it.
+	mpit_init(jobid, world_rank);
+
 	// Allocate buffers reused between alltoallv calls
 	// Note the buffer may be used on a communicator that is not comm_world
 	// but in any case, it will be smaller or of the same size than comm_world.
@@ -797,6 +1139,12 @@ int _mpi_init(int *argc, char ***argv)
 	if (dump_call_data_envvar != NULL)
 		dump_call_data = atoi(dump_call_data_envvar);
 
+#if ENABLE_EXEC_TIMING || ENABLE_LATE_ARRIVAL_TIMING
+	// Estimate the clock offset of every rank relative to rank 0 so the
+	// post-mortem analysis can correct cross-node timings for clock skew.
+	save_clock_offsets(jobid, world_rank, world_size);
+#endif // ENABLE_EXEC_TIMING || ENABLE_LATE_ARRIVAL_TIMING
+
 	// Make sure we do not create an articial imbalance between ranks.
 	PMPI_Barrier(MPI_COMM_WORLD);
 
@@ -835,6 +1183,16 @@ int _mpi_init_thread(int *argc, char ***argv, int required, int *provided)
 	logger = logger_init(jobid, world_rank, world_size, &alltoallv_logger_cfg);
 	assert(logger);
 
+	// Save the tuning variables from the environment; they are assumed to be
+	// homogeneous across ranks so rank 0 saves them once for the entire job.
+	if (world_rank == 0)
+	{
+		save_exec_env(jobid, world_rank);
+	}
+
+	// Set up the MPI_T performance variable capture if the user requested it.
+	mpit_init(jobid, world_rank);
+
 	// Allocate buffers reused between alltoallv calls
 	// Note the buffer may be used on a communicator that is not comm_world
 	// but in any case, it will be smaller or of the same size than comm_world.
@@ -878,6 +1236,12 @@ int _mpi_init_thread(int *argc, char ***argv, int required, int *provided)
 	if (dump_call_data_envvar != NULL)
 		dump_call_data = atoi(dump_call_data_envvar);
 
+#if ENABLE_EXEC_TIMING || ENABLE_LATE_ARRIVAL_TIMING
+	// Estimate the clock offset of every rank relative to rank 0 so the
+	// post-mortem analysis can correct cross-node timings for clock skew.
+	save_clock_offsets(jobid, world_rank, world_size);
+#endif // ENABLE_EXEC_TIMING || ENABLE_LATE_ARRIVAL_TIMING
+
 	// Make sure we do not create an articial imbalance between ranks.
 	PMPI_Barrier(MPI_COMM_WORLD);
 
@@ -955,6 +1319,34 @@ static int _release_counts_resources()
 	return 0;
 }
 
+static int _release_displs_resources()
+{
+	// All data has been handled, now we can clean up
+	int i;
+	while (displs_head != NULL)
+	{
+		SRDisplNode_t *d_ptr = displs_head->next;
+
+		for (i = 0; i < displs_head->send_data_size; i++)
+		{
+			delete_displs_data(&(displs_head->send_data[i]));
+		}
+
+		for (i = 0; i < displs_head->recv_data_size; i++)
+		{
+			delete_displs_data(&(displs_head->recv_data[i]));
+		}
+
+		free(displs_head->recv_data);
+		free(displs_head->send_data);
+		free(displs_head->list_calls);
+
+		free(displs_head);
+		displs_head = d_ptr;
+	}
+	return 0;
+}
+
 static int _release_pattern_resources()
 {
 	while (rpatterns != NULL)
@@ -980,6 +1372,10 @@ static int _release_profiling_resources()
 	_release_counts_resources();
 #endif // ENABLE_RAW_DATA || ENABLE_VALIDATION
 
+#if ENABLE_DISPLS
+	_release_displs_resources();
+#endif // ENABLE_DISPLS
+
 	while (op_timing_exec_head != NULL)
 	{
 		avTimingsNode_t *t_ptr = op_timing_exec_head->next;
@@ -1040,6 +1436,8 @@ static int _release_profiling_resources()
 static int _finalize_profiling()
 {
 	logger_fini(&logger);
+	mpit_fini();
+	counts_checks_fini();
 	_release_profiling_resources();
 }
 
@@ -1243,9 +1641,19 @@ int _mpi_alltoallv(const void *sendbuf, const int *sendcounts, const int *sdispl
 		double t_arrival = t_barrier_end - t_barrier_start;
 #endif // ENABLE_LATE_ARRIVAL_TIMING
 
+		// Read the tracked MPI_T performance variables right after the
+		// operation, while its effect on the transport is still visible.
+		mpit_record(collective_name, avCalls);
+
+#if ENABLE_DISPLS
+		// Gather send/recv displacements
+		PMPI_Gather(sdispls, comm_size, MPI_INT, sbuf, comm_size, MPI_INT, 0, comm);
+		PMPI_Gather(rdispls, comm_size, MPI_INT, rbuf, comm_size, MPI_INT, 0, comm);
+#else
 		// Gather a bunch of counters
 		PMPI_Gather(sendcounts, comm_size, MPI_INT, sbuf, comm_size, MPI_INT, 0, comm);
 		PMPI_Gather(recvcounts, comm_size, MPI_INT, rbuf, comm_size, MPI_INT, 0, comm);
+#endif // ENABLE_DISPLS
 
 #if ENABLE_EXEC_TIMING
 		PMPI_Gather(&t_op, 1, MPI_DOUBLE, op_exec_times, 1, MPI_DOUBLE, 0, comm);
@@ -1348,6 +1756,27 @@ int _mpi_alltoallv(const void *sendbuf, const int *sendcounts, const int *sdispl
 			fprintf(logger->f, "Root: global %d - %d   local %d - %d\n", world_size, myrank, size, localrank);
 #endif
 
+#if ENABLE_DISPLS
+			DEBUG_ALLTOALLV_PROFILING("Saving displacement data of call #%" PRIu64 ".\n", avCalls);
+			int s_dt_size, r_dt_size;
+			PMPI_Type_size(sendtype, &s_dt_size);
+			PMPI_Type_size(recvtype, &r_dt_size);
+			if (insert_sendrecv_displ_data(sbuf, rbuf, comm_size, s_dt_size, r_dt_size))
+			{
+				fprintf(stderr, "[%s:%d][ERROR] unable to insert send/recv displacements\n", __FILE__, __LINE__);
+				PMPI_Abort(MPI_COMM_WORLD, 1);
+			}
+#endif // ENABLE_DISPLS
+
+#if ENABLE_COUNTS_CHECKS && !ENABLE_DISPLS
+			{
+				int _s_dt_size, _r_dt_size;
+				PMPI_Type_size(sendtype, &_s_dt_size);
+				PMPI_Type_size(recvtype, &_r_dt_size);
+				check_collective_counts(collective_name, avCalls, comm_size, sbuf, comm_size, rbuf, comm_size, _s_dt_size, _r_dt_size, get_job_id(), world_rank);
+			}
+#endif // ENABLE_COUNTS_CHECKS && !ENABLE_DISPLS
+
 #if ((ENABLE_RAW_DATA || ENABLE_PER_RANK_STATS || ENABLE_VALIDATION) && ENABLE_COMPACT_FORMAT)
 			DEBUG_ALLTOALLV_PROFILING("Saving data of call #%" PRIu64 ".\n", avCalls);
 			int s_dt_size, r_dt_size;
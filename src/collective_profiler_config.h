@@ -63,6 +63,11 @@
 #define ENABLE_COMPARE_DATA_VALIDATION (0)
 #endif // ENABLE_COMPARE_DATA_VALIDATION
 
+// Switch to enable/disable MPI standard compliance checks on the gathered counts
+#ifndef ENABLE_COUNTS_CHECKS
+#define ENABLE_COUNTS_CHECKS (0)
+#endif // ENABLE_COUNTS_CHECKS
+
 // A few switches that are less commonly used by users and that cannot be set a compiling time from the compiler command
 #define ENABLE_LIVE_GROUPING (0)         // Switch to enable/disable live grouping (can be very time consuming)
 #define ENABLE_POSTMORTEM_GROUPING (0)   // Switch to enable/disable post-mortem grouping analysis (when enabled, data will be saved to a file)
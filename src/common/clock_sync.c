@@ -0,0 +1,108 @@
+/*************************************************************************
+ * Copyright (c) 2022, NVIDIA CORPORATION. All rights reserved.
+ *
+ * See LICENSE.txt for license information
+ ************************************************************************/
+
+#include <stdio.h>
+#include <stdlib.h>
+#include <assert.h>
+
+#include "clock_sync.h"
+#include "logger.h"
+#include "collective_profiler_config.h"
+#include "common_utils.h"
+#include "format.h"
+
+// Number of ping-pongs used to estimate the offset of a rank's clock. We
+// keep the estimate associated with the smallest round-trip time, which is
+// the one least affected by network noise.
+#define CLOCK_SYNC_NUM_PROBES (10)
+#define CLOCK_SYNC_TAG (4242)
+
+static int _save_offsets_to_file(int jobid, int world_rank, int world_size, double *offsets)
+{
+    char *filename = NULL;
+    int rc;
+    int i;
+    char *dir = get_output_dir();
+
+    if (dir != NULL)
+    {
+        _asprintf(filename, rc, "%s/clock_offsets.job%d.rank%d.md", dir, jobid, world_rank);
+    }
+    else
+    {
+        _asprintf(filename, rc, "clock_offsets.job%d.rank%d.md", jobid, world_rank);
+    }
+    assert(rc > 0);
+
+    FILE *fd = fopen(filename, "w");
+    if (fd == NULL)
+    {
+        fprintf(stderr, "[%s:%d][ERROR] unable to create %s\n", __FILE__, __LINE__, filename);
+        free(filename);
+        return 1;
+    }
+
+    FORMAT_VERSION_WRITE(fd);
+    fprintf(fd, "# Clock offsets\n\n");
+    fprintf(fd, "Reference rank: 0\n");
+    fprintf(fd, "Number of ranks: %d\n\n", world_size);
+    for (i = 0; i < world_size; i++)
+    {
+        fprintf(fd, "Rank %d: %.9f\n", i, offsets[i]);
+    }
+
+    fclose(fd);
+    free(filename);
+    return 0;
+}
+
+int save_clock_offsets(int jobid, int world_rank, int world_size)
+{
+    int i;
+
+    if (world_rank == 0)
+    {
+        double *offsets = (double *)malloc(world_size * sizeof(double));
+        assert(offsets);
+        offsets[0] = 0.0;
+
+        int rank;
+        for (rank = 1; rank < world_size; rank++)
+        {
+            double best_rtt = -1.0;
+            double offset = 0.0;
+            for (i = 0; i < CLOCK_SYNC_NUM_PROBES; i++)
+            {
+                double t_remote;
+                double t_start = MPI_Wtime();
+                PMPI_Send(&t_start, 1, MPI_DOUBLE, rank, CLOCK_SYNC_TAG, MPI_COMM_WORLD);
+                PMPI_Recv(&t_remote, 1, MPI_DOUBLE, rank, CLOCK_SYNC_TAG, MPI_COMM_WORLD, MPI_STATUS_IGNORE);
+                double rtt = MPI_Wtime() - t_start;
+                if (best_rtt < 0.0 || rtt < best_rtt)
+                {
+                    // Assuming the network is symmetric, the remote clock was
+                    // read rtt/2 after t_start.
+                    best_rtt = rtt;
+                    offset = t_remote - (t_start + rtt / 2.0);
+                }
+            }
+            offsets[rank] = offset;
+        }
+
+        int rc = _save_offsets_to_file(jobid, world_rank, world_size, offsets);
+        free(offsets);
+        return rc;
+    }
+
+    for (i = 0; i < CLOCK_SYNC_NUM_PROBES; i++)
+    {
+        double t_remote;
+        PMPI_Recv(&t_remote, 1, MPI_DOUBLE, 0, CLOCK_SYNC_TAG, MPI_COMM_WORLD, MPI_STATUS_IGNORE);
+        t_remote = MPI_Wtime();
+        PMPI_Send(&t_remote, 1, MPI_DOUBLE, 0, CLOCK_SYNC_TAG, MPI_COMM_WORLD);
+    }
+    return 0;
+}
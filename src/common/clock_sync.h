@@ -0,0 +1,28 @@
+/*************************************************************************
+ * Copyright (c) 2022, NVIDIA CORPORATION. All rights reserved.
+ *
+ * See LICENSE.txt for license information
+ ************************************************************************/
+
+#ifndef COLLECTIVE_PROFILER_CLOCK_SYNC_H
+#define COLLECTIVE_PROFILER_CLOCK_SYNC_H
+
+#include "mpi.h"
+
+/**
+ * @brief save_clock_offsets estimates, for every rank on COMM_WORLD, the
+ * offset of its clock relative to the clock of rank 0 and saves the result
+ * to a file. The post-mortem analysis tools can use these offsets to
+ * correct cross-node timings (e.g., late arrival times) for clock skew
+ * when MPI_Wtime is not globally synchronized.
+ *
+ * All ranks on COMM_WORLD must call the function; only rank 0 writes the
+ * resulting clock_offsets.job<JOBID>.rank<RANK>.md file.
+ *
+ * @param jobid Job identifier, used to compose the output file name
+ * @param world_rank Rank on COMM_WORLD of the calling process
+ * @param world_size Size of COMM_WORLD
+ */
+extern int save_clock_offsets(int jobid, int world_rank, int world_size);
+
+#endif // COLLECTIVE_PROFILER_CLOCK_SYNC_H
@@ -0,0 +1,136 @@
+/*************************************************************************
+ * Copyright (c) 2022, NVIDIA CORPORATION. All rights reserved.
+ *
+ * See LICENSE.txt for license information
+ ************************************************************************/
+
+#include <stdio.h>
+#include <stdlib.h>
+#include <limits.h>
+#include <assert.h>
+
+#include "counts_checks.h"
+#include "collective_profiler_config.h"
+#include "common_utils.h"
+#include "logger.h"
+#include "format.h"
+
+static FILE *checks_fd = NULL;
+
+static FILE *open_checks_file(int jobid, int world_rank)
+{
+    char *filename = NULL;
+    int rc;
+    char *dir = get_output_dir();
+    if (dir != NULL)
+    {
+        _asprintf(filename, rc, "%s/counts-checks.job%d.rank%d.md", dir, jobid, world_rank);
+    }
+    else
+    {
+        _asprintf(filename, rc, "counts-checks.job%d.rank%d.md", jobid, world_rank);
+    }
+    assert(rc > 0);
+
+    FILE *fd = fopen(filename, "w");
+    if (fd == NULL)
+    {
+        fprintf(stderr, "[%s:%d][ERROR] unable to create %s\n", __FILE__, __LINE__, filename);
+        free(filename);
+        return NULL;
+    }
+    free(filename);
+    FORMAT_VERSION_WRITE(fd);
+    fprintf(fd, "# Counts sanity checks\n\n");
+    return fd;
+}
+
+// Check one gathered count matrix (send or recv side). Returns the number of
+// problems found and reports each of them.
+static int _check_counts(char *collective_name,
+                         uint64_t n_call,
+                         char *context,
+                         int comm_size,
+                         int *counts,
+                         int vec_len,
+                         int type_size)
+{
+    int rank, n;
+    int num_problems = 0;
+
+    for (rank = 0; rank < comm_size; rank++)
+    {
+        for (n = 0; n < vec_len; n++)
+        {
+            int count = counts[rank * vec_len + n];
+            if (count < 0)
+            {
+                fprintf(stderr, "[%s:%d][WARNING] %s call %" PRIu64 ": rank %d has a negative %s count (%d)\n",
+                        __FILE__, __LINE__, collective_name, n_call, rank, context, count);
+                fprintf(checks_fd, "Call %" PRIu64 ": rank %d, %s count %d: negative count (%d)\n",
+                        n_call, rank, context, n, count);
+                num_problems++;
+                continue;
+            }
+            if ((long long)count * (long long)type_size > (long long)INT_MAX)
+            {
+                fprintf(stderr, "[%s:%d][WARNING] %s call %" PRIu64 ": rank %d has a %s count that overflows INT_MAX once scaled by the datatype size (%d x %d)\n",
+                        __FILE__, __LINE__, collective_name, n_call, rank, context, count, type_size);
+                fprintf(checks_fd, "Call %" PRIu64 ": rank %d, %s count %d: %d x %d bytes exceeds INT_MAX\n",
+                        n_call, rank, context, n, count, type_size);
+                num_problems++;
+            }
+        }
+    }
+
+    return num_problems;
+}
+
+int check_collective_counts(char *collective_name,
+                            uint64_t n_call,
+                            int comm_size,
+                            int *send_counts,
+                            int send_vec_len,
+                            int *recv_counts,
+                            int recv_vec_len,
+                            int sendtype_size,
+                            int recvtype_size,
+                            int jobid,
+                            int world_rank)
+{
+    int num_problems = 0;
+
+    assert(send_counts);
+    assert(recv_counts);
+
+    if (checks_fd == NULL)
+    {
+        checks_fd = open_checks_file(jobid, world_rank);
+        if (checks_fd == NULL)
+        {
+            return -1;
+        }
+    }
+
+    num_problems += _check_counts(collective_name, n_call, "send", comm_size, send_counts, send_vec_len, sendtype_size);
+    num_problems += _check_counts(collective_name, n_call, "recv", comm_size, recv_counts, recv_vec_len, recvtype_size);
+
+    if (num_problems > 0)
+    {
+        // Bad counts frequently precede an application abort; make sure the
+        // records survive an abnormal termination.
+        fflush(checks_fd);
+    }
+
+    return num_problems;
+}
+
+int counts_checks_fini()
+{
+    if (checks_fd != NULL)
+    {
+        fclose(checks_fd);
+        checks_fd = NULL;
+    }
+    return 0;
+}
@@ -0,0 +1,52 @@
+/*************************************************************************
+ * Copyright (c) 2022, NVIDIA CORPORATION. All rights reserved.
+ *
+ * See LICENSE.txt for license information
+ ************************************************************************/
+
+#ifndef COLLECTIVE_PROFILER_COUNTS_CHECKS_H
+#define COLLECTIVE_PROFILER_COUNTS_CHECKS_H
+
+#include <inttypes.h>
+
+/**
+ * @brief check_collective_counts runs MPI standard compliance checks on the
+ * counts gathered on the root rank for a collective call: negative counts
+ * and counts that overflow INT_MAX once multiplied by the datatype size.
+ * Problems are reported on stderr and appended to a
+ * counts-checks.job<JOBID>.rank<RANK>.md file so the post-mortem tools can
+ * pick them up. These conditions point at application bugs that the
+ * profiler would otherwise silently record.
+ *
+ * @param collective_name Name of the collective being profiled
+ * @param n_call Number of the collective call on the current rank
+ * @param comm_size Size of the communicator used for the collective
+ * @param send_counts Gathered send counts (comm_size * send_vec_len elements)
+ * @param send_vec_len Number of send counts per rank
+ * @param recv_counts Gathered recv counts (comm_size * recv_vec_len elements)
+ * @param recv_vec_len Number of recv counts per rank
+ * @param sendtype_size Size in bytes of the send datatype
+ * @param recvtype_size Size in bytes of the receive datatype
+ * @param jobid Job identifier, used to compose the output file name
+ * @param world_rank Rank on COMM_WORLD of the calling process
+ * @return int 0 when the counts pass all the checks, the number of problems found otherwise
+ */
+extern int check_collective_counts(char *collective_name,
+                                   uint64_t n_call,
+                                   int comm_size,
+                                   int *send_counts,
+                                   int send_vec_len,
+                                   int *recv_counts,
+                                   int recv_vec_len,
+                                   int sendtype_size,
+                                   int recvtype_size,
+                                   int jobid,
+                                   int world_rank);
+
+/**
+ * @brief counts_checks_fini closes the checks file when one was created.
+ * Safe to call multiple times.
+ */
+extern int counts_checks_fini();
+
+#endif // COLLECTIVE_PROFILER_COUNTS_CHECKS_H
@@ -0,0 +1,84 @@
+/*************************************************************************
+ * Copyright (c) 2022, NVIDIA CORPORATION. All rights reserved.
+ *
+ * See LICENSE.txt for license information
+ ************************************************************************/
+
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <assert.h>
+
+#include "exec_env.h"
+#include "logger.h"
+#include "collective_profiler_config.h"
+#include "common_utils.h"
+#include "format.h"
+
+extern char **environ;
+
+// Prefixes identifying the tuning variables worth saving. Most cross-run
+// performance deltas can be explained by one of these being different.
+static const char *tuning_var_prefixes[] = {
+    "OMPI_MCA_",
+    "UCX_",
+    "HCOLL_",
+    "SHARP_COLL_",
+    "I_MPI_",
+    "MPICH_",
+    "MV2_",
+    NULL,
+};
+
+static int is_tuning_var(const char *entry)
+{
+    int i;
+    for (i = 0; tuning_var_prefixes[i] != NULL; i++)
+    {
+        if (strncmp(entry, tuning_var_prefixes[i], strlen(tuning_var_prefixes[i])) == 0)
+        {
+            return 1;
+        }
+    }
+    return 0;
+}
+
+int save_exec_env(int jobid, int world_rank)
+{
+    char *filename = NULL;
+    int rc;
+    int i;
+    char *dir = get_output_dir();
+
+    if (dir != NULL)
+    {
+        _asprintf(filename, rc, "%s/exec_env.job%d.rank%d.md", dir, jobid, world_rank);
+    }
+    else
+    {
+        _asprintf(filename, rc, "exec_env.job%d.rank%d.md", jobid, world_rank);
+    }
+    assert(rc > 0);
+
+    FILE *fd = fopen(filename, "w");
+    if (fd == NULL)
+    {
+        fprintf(stderr, "[%s:%d][ERROR] unable to create %s\n", __FILE__, __LINE__, filename);
+        free(filename);
+        return 1;
+    }
+
+    FORMAT_VERSION_WRITE(fd);
+    fprintf(fd, "# Tuning environment\n\n");
+    for (i = 0; environ[i] != NULL; i++)
+    {
+        if (is_tuning_var(environ[i]))
+        {
+            fprintf(fd, "%s\n", environ[i]);
+        }
+    }
+
+    fclose(fd);
+    free(filename);
+    return 0;
+}
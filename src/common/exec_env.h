@@ -0,0 +1,22 @@
+/*************************************************************************
+ * Copyright (c) 2022, NVIDIA CORPORATION. All rights reserved.
+ *
+ * See LICENSE.txt for license information
+ ************************************************************************/
+
+#ifndef COLLECTIVE_PROFILER_EXEC_ENV_H
+#define COLLECTIVE_PROFILER_EXEC_ENV_H
+
+/**
+ * @brief save_exec_env saves the MPI and network tuning variables found in
+ * the environment (Open MPI MCA parameters, UCX, HCOLL settings, and so on)
+ * to a file so post-mortem analysis can correlate performance differences
+ * between runs with tuning differences. Only rank 0 on COMM_WORLD is
+ * expected to call it, once, during initialization.
+ *
+ * @param jobid Job identifier, used to compose the output file name
+ * @param world_rank Rank on COMM_WORLD of the calling process
+ */
+extern int save_exec_env(int jobid, int world_rank);
+
+#endif // COLLECTIVE_PROFILER_EXEC_ENV_H
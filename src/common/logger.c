@@ -482,8 +482,10 @@ logger_t *logger_init(int jobid, int world_rank, int world_size, logger_config_t
     l->f = NULL;
     l->main_filename = NULL;
     l->recvcounters_fh = NULL;
+    l->recvcounts_meta_fh = NULL;
     l->recvcounts_filename = NULL;
     l->sendcounters_fh = NULL;
+    l->sendcounts_meta_fh = NULL;
     l->sendcounts_filename = NULL;
     l->sums_fh = NULL;
     l->sums_filename = NULL;
@@ -540,10 +542,14 @@ void logger_fini(logger_t **l)
         free((*l)->main_filename);
     if ((*l)->sendcounters_fh)
         fclose((*l)->sendcounters_fh);
+    if ((*l)->sendcounts_meta_fh)
+        fclose((*l)->sendcounts_meta_fh);
     if ((*l)->sendcounts_filename)
         free((*l)->sendcounts_filename);
     if ((*l)->recvcounters_fh)
         fclose((*l)->recvcounters_fh);
+    if ((*l)->recvcounts_meta_fh)
+        fclose((*l)->recvcounts_meta_fh);
     if ((*l)->recvcounts_filename)
         free((*l)->recvcounts_filename);
     if ((*l)->timing_fh)
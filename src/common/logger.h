@@ -49,8 +49,10 @@ typedef struct logger
     FILE *f;                   // File handle to save general profile data. Other files are created for specific data.
     char *sendcounts_filename; // Path of the send counts profile.
     FILE *sendcounters_fh;     // File handle used to save send counters.
+    FILE *sendcounts_meta_fh;  // File handle used to save the send counts metadata sidecar.
     char *recvcounts_filename; // Path of the receive counts profile.
     FILE *recvcounters_fh;     // File handle used to save recv counters.
+    FILE *recvcounts_meta_fh;  // File handle used to save the recv counts metadata sidecar.
     char *senddispls_filename; // Path of the send displacements profile.
     FILE *senddispls_fh;       // File handle used to save send displacements.
     char *recvdispls_filename; // Path of the receive displacements profile.
@@ -19,6 +19,31 @@
 #include "logger.h"
 #include "grouping.h"
 #include "format.h"
+#include "common_utils.h"
+
+// Open the metadata sidecar associated with a counters file. The sidecar
+// records, for every block of the counters file, the block's byte offset and
+// the number of calls it covers, so post-mortem tools can get the total
+// number of calls and seek to a block without scanning the entire file.
+static FILE *open_counts_meta_file(char *counts_filename)
+{
+    char *meta_filename = NULL;
+    int rc;
+    assert(counts_filename);
+    _asprintf(meta_filename, rc, "%s.meta", counts_filename);
+    assert(rc > 0);
+    FILE *fh = fopen(meta_filename, "w");
+    if (fh == NULL)
+    {
+        fprintf(stderr, "[%s:%d][ERROR] unable to create %s\n", __FILE__, __LINE__, meta_filename);
+        free(meta_filename);
+        return NULL;
+    }
+    free(meta_filename);
+    FORMAT_VERSION_WRITE(fh);
+    fprintf(fh, "# Counters metadata\n\n");
+    return fh;
+}
 
 int *lookup_rank_counters(int data_size, counts_data_t **data, int rank)
 {
@@ -56,6 +81,7 @@ int log_counts(logger_t *logger,
                int type_size)
 {
     FILE *fh = NULL;
+    FILE *meta_fh = NULL;
     assert(logger);
     assert(calls);
     assert(counters);
@@ -66,8 +92,10 @@ int log_counts(logger_t *logger,
         {
             logger->recvcounts_filename = logger->get_full_filename(RECV_CTX, "counters", logger->jobid, logger->rank);
             logger->recvcounters_fh = fopen(logger->recvcounts_filename, "w");
+            logger->recvcounts_meta_fh = open_counts_meta_file(logger->recvcounts_filename);
         }
         fh = logger->recvcounters_fh;
+        meta_fh = logger->recvcounts_meta_fh;
         break;
 
     case SEND_CTX:
@@ -75,8 +103,10 @@ int log_counts(logger_t *logger,
         {
             logger->sendcounts_filename = logger->get_full_filename(SEND_CTX, "counters", logger->jobid, logger->rank);
             logger->sendcounters_fh = fopen(logger->sendcounts_filename, "w");
+            logger->sendcounts_meta_fh = open_counts_meta_file(logger->sendcounts_filename);
         }
         fh = logger->sendcounters_fh;
+        meta_fh = logger->sendcounts_meta_fh;
         break;
 
     default:
@@ -85,6 +115,11 @@ int log_counts(logger_t *logger,
     }
     assert(fh);
 
+    if (meta_fh != NULL)
+    {
+        fprintf(meta_fh, "Block offset %ld: %" PRIu64 " calls\n", ftell(fh), count);
+    }
+
     fprintf(fh, "# Raw counters\n\n");
     fprintf(fh, "Number of ranks: %d\n", size);
     fprintf(fh, "Datatype size: %d\n", type_size);
@@ -0,0 +1,248 @@
+/*************************************************************************
+ * Copyright (c) 2022, NVIDIA CORPORATION. All rights reserved.
+ *
+ * See LICENSE.txt for license information
+ ************************************************************************/
+
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <assert.h>
+
+#include "mpi.h"
+
+#include "mpit.h"
+#include "logger.h"
+#include "collective_profiler_config.h"
+#include "common_utils.h"
+#include "format.h"
+
+#define MAX_TRACKED_PVARS (32)
+#define MPIT_NAME_LEN (256)
+
+typedef struct tracked_pvar
+{
+    char name[MPIT_NAME_LEN];
+    MPI_T_pvar_handle handle;
+    MPI_Datatype datatype;
+    int count;
+} tracked_pvar_t;
+
+static tracked_pvar_t tracked_pvars[MAX_TRACKED_PVARS];
+static int num_tracked_pvars = 0;
+static MPI_T_pvar_session pvar_session;
+static FILE *mpit_fd = NULL;
+static int mpit_active = 0;
+
+// Find the index of a pvar from its name. Returns -1 when the MPI
+// implementation does not expose a pvar with that name.
+static int _lookup_pvar_index(const char *name, MPI_Datatype *datatype)
+{
+    int num_pvars = 0;
+    int i;
+
+    MPI_T_pvar_get_num(&num_pvars);
+    for (i = 0; i < num_pvars; i++)
+    {
+        char pvar_name[MPIT_NAME_LEN];
+        char desc[MPIT_NAME_LEN];
+        int name_len = MPIT_NAME_LEN;
+        int desc_len = MPIT_NAME_LEN;
+        int verbosity, var_class, binding, readonly, continuous, atomic;
+        MPI_T_enum enumtype;
+
+        if (MPI_T_pvar_get_info(i, pvar_name, &name_len, &verbosity, &var_class,
+                                datatype, &enumtype, desc, &desc_len, &binding,
+                                &readonly, &continuous, &atomic) != MPI_SUCCESS)
+        {
+            continue;
+        }
+
+        if (strcmp(pvar_name, name) == 0)
+        {
+            return i;
+        }
+    }
+    return -1;
+}
+
+int mpit_init(int jobid, int world_rank)
+{
+    char *pvars_list = getenv(MPIT_PVARS_ENVVAR);
+    if (pvars_list == NULL)
+    {
+        return 0;
+    }
+
+    int provided;
+    if (MPI_T_init_thread(MPI_THREAD_SINGLE, &provided) != MPI_SUCCESS)
+    {
+        fprintf(stderr, "[%s:%d][ERROR] MPI_T_init_thread() failed, MPI_T capture disabled\n", __FILE__, __LINE__);
+        return 1;
+    }
+
+    if (MPI_T_pvar_session_create(&pvar_session) != MPI_SUCCESS)
+    {
+        fprintf(stderr, "[%s:%d][ERROR] unable to create MPI_T session, MPI_T capture disabled\n", __FILE__, __LINE__);
+        MPI_T_finalize();
+        return 1;
+    }
+
+    char *filename = NULL;
+    int rc;
+    char *dir = get_output_dir();
+    if (dir != NULL)
+    {
+        _asprintf(filename, rc, "%s/mpit.job%d.rank%d.md", dir, jobid, world_rank);
+    }
+    else
+    {
+        _asprintf(filename, rc, "mpit.job%d.rank%d.md", jobid, world_rank);
+    }
+    assert(rc > 0);
+
+    mpit_fd = fopen(filename, "w");
+    if (mpit_fd == NULL)
+    {
+        fprintf(stderr, "[%s:%d][ERROR] unable to create %s\n", __FILE__, __LINE__, filename);
+        free(filename);
+        return 1;
+    }
+    free(filename);
+    FORMAT_VERSION_WRITE(mpit_fd);
+    fprintf(mpit_fd, "# MPI_T performance variables\n\n");
+
+    // The list is parsed on a copy since strtok modifies its argument
+    char *list_copy = strdup(pvars_list);
+    assert(list_copy);
+    char *token = strtok(list_copy, ",");
+    while (token != NULL && num_tracked_pvars < MAX_TRACKED_PVARS)
+    {
+        MPI_Datatype datatype;
+        int idx = _lookup_pvar_index(token, &datatype);
+        if (idx < 0)
+        {
+            fprintf(mpit_fd, "Unavailable: %s\n", token);
+        }
+        else
+        {
+            tracked_pvar_t *pvar = &tracked_pvars[num_tracked_pvars];
+            if (MPI_T_pvar_handle_alloc(pvar_session, idx, NULL, &(pvar->handle), &(pvar->count)) == MPI_SUCCESS)
+            {
+                strncpy(pvar->name, token, MPIT_NAME_LEN - 1);
+                pvar->name[MPIT_NAME_LEN - 1] = '\0';
+                pvar->datatype = datatype;
+                fprintf(mpit_fd, "Tracked: %s\n", pvar->name);
+                num_tracked_pvars++;
+            }
+            else
+            {
+                fprintf(mpit_fd, "Unavailable: %s\n", token);
+            }
+        }
+        token = strtok(NULL, ",");
+    }
+    free(list_copy);
+    fprintf(mpit_fd, "\n");
+
+    mpit_active = 1;
+    return 0;
+}
+
+int mpit_record(char *collective_name, uint64_t n_call)
+{
+    int i, n;
+
+    if (!mpit_active)
+    {
+        return 0;
+    }
+    assert(mpit_fd);
+
+    fprintf(mpit_fd, "# %s call %" PRIu64 "\n", collective_name, n_call);
+    for (i = 0; i < num_tracked_pvars; i++)
+    {
+        tracked_pvar_t *pvar = &(tracked_pvars[i]);
+        if (pvar->count <= 0)
+        {
+            continue;
+        }
+        // A buffer of unsigned long long elements is big enough for all the
+        // datatypes the standard allows for pvars; the values are decoded
+        // below at the width of the pvar's actual datatype.
+        unsigned long long values[pvar->count];
+        if (MPI_T_pvar_read(pvar_session, pvar->handle, values) != MPI_SUCCESS)
+        {
+            fprintf(mpit_fd, "%s: read error\n", pvar->name);
+            continue;
+        }
+        fprintf(mpit_fd, "%s:", pvar->name);
+        for (n = 0; n < pvar->count; n++)
+        {
+            if (pvar->datatype == MPI_DOUBLE)
+            {
+                fprintf(mpit_fd, " %f", ((double *)values)[n]);
+            }
+            else if (pvar->datatype == MPI_INT)
+            {
+                fprintf(mpit_fd, " %d", ((int *)values)[n]);
+            }
+            else if (pvar->datatype == MPI_UNSIGNED)
+            {
+                fprintf(mpit_fd, " %u", ((unsigned int *)values)[n]);
+            }
+            else if (pvar->datatype == MPI_UNSIGNED_LONG)
+            {
+                fprintf(mpit_fd, " %lu", ((unsigned long *)values)[n]);
+            }
+            else if (pvar->datatype == MPI_UNSIGNED_LONG_LONG)
+            {
+                fprintf(mpit_fd, " %llu", values[n]);
+            }
+            else if (pvar->datatype == MPI_COUNT)
+            {
+                fprintf(mpit_fd, " %lld", (long long)((MPI_Count *)values)[n]);
+            }
+            else if (pvar->datatype == MPI_CHAR)
+            {
+                fprintf(mpit_fd, " %d", (int)((char *)values)[n]);
+            }
+            else
+            {
+                // The standard only allows the datatypes handled above for
+                // performance variables; do not guess a width for anything else.
+                fprintf(mpit_fd, " unsupported datatype");
+                break;
+            }
+        }
+        fprintf(mpit_fd, "\n");
+    }
+    fprintf(mpit_fd, "\n");
+    return 0;
+}
+
+int mpit_fini()
+{
+    int i;
+
+    if (!mpit_active)
+    {
+        return 0;
+    }
+    mpit_active = 0;
+
+    for (i = 0; i < num_tracked_pvars; i++)
+    {
+        MPI_T_pvar_handle_free(pvar_session, &(tracked_pvars[i].handle));
+    }
+    num_tracked_pvars = 0;
+    MPI_T_pvar_session_free(&pvar_session);
+    MPI_T_finalize();
+
+    if (mpit_fd != NULL)
+    {
+        fclose(mpit_fd);
+        mpit_fd = NULL;
+    }
+    return 0;
+}
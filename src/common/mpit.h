@@ -0,0 +1,47 @@
+/*************************************************************************
+ * Copyright (c) 2022, NVIDIA CORPORATION. All rights reserved.
+ *
+ * See LICENSE.txt for license information
+ ************************************************************************/
+
+#ifndef COLLECTIVE_PROFILER_MPIT_H
+#define COLLECTIVE_PROFILER_MPIT_H
+
+#include <inttypes.h>
+
+// Name of the environment variable listing the MPI_T performance variables
+// to capture (comma-separated pvar names). When the variable is not set,
+// the MPI_T capture is entirely disabled.
+#define MPIT_PVARS_ENVVAR "COLLECTIVE_PROFILER_MPIT_PVARS"
+
+/**
+ * @brief mpit_init looks up the MPI_T performance variables requested via
+ * the COLLECTIVE_PROFILER_MPIT_PVARS environment variable and prepares a
+ * session to read them. Each rank captures its own values into a
+ * mpit.job<JOBID>.rank<RANK>.md file. Requested pvars that the MPI
+ * implementation does not expose are reported in the file header and
+ * skipped. The function is a no-op when the environment variable is not
+ * set.
+ *
+ * @param jobid Job identifier, used to compose the output file name
+ * @param world_rank Rank on COMM_WORLD of the calling process
+ */
+extern int mpit_init(int jobid, int world_rank);
+
+/**
+ * @brief mpit_record reads all the tracked performance variables and
+ * appends their values to the rank's capture file, tagged with the
+ * collective call number. No-op when the capture is disabled.
+ *
+ * @param collective_name Name of the collective being profiled
+ * @param n_call Number of the collective call on the current rank
+ */
+extern int mpit_record(char *collective_name, uint64_t n_call);
+
+/**
+ * @brief mpit_fini releases the MPI_T session and handles and closes the
+ * capture file. Safe to call multiple times.
+ */
+extern int mpit_fini();
+
+#endif // COLLECTIVE_PROFILER_MPIT_H
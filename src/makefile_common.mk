@@ -4,4 +4,4 @@
 #
 
 # Avoid duplicating the list of common objects is makefiles.
-COMMON_OBJECTS=../common/format.o ../common/comm.o ../common/backtrace.o ../common/grouping.o ../common/location.o
\ No newline at end of file
+COMMON_OBJECTS=../common/format.o ../common/comm.o ../common/backtrace.o ../common/grouping.o ../common/location.o ../common/exec_env.o ../common/mpit.o ../common/counts_checks.o
\ No newline at end of file